@@ -0,0 +1,110 @@
+// Package config loads lsp-node-stats' YAML configuration file.
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChannelOpenConfig configures the threshold and funding-lookup channel-open
+// classifiers.
+type ChannelOpenConfig struct {
+	MinPpm               uint64  `yaml:"min_ppm"`
+	MaxPpm               *uint64 `yaml:"max_ppm,omitempty"`
+	MinOutMsat           uint64  `yaml:"min_out_msat"`
+	FundingWindowSeconds float64 `yaml:"funding_window_seconds"`
+}
+
+// NodeConfig describes one LSP node to ingest and report on. Exactly one of
+// ClnSocket, LndAddress, or the file fields is expected to be set: ingestAll
+// (via nodeClientFor) uses ClnSocket if it is non-empty, else LndAddress if
+// it is non-empty, and otherwise falls back to the file fields.
+type NodeConfig struct {
+	Name               string `yaml:"name"`
+	Pubkey             string `yaml:"pubkey"`
+	ForwardsFile       string `yaml:"forwards_file,omitempty"`
+	ChannelsFile       string `yaml:"channels_file,omitempty"`
+	ClosedChannelsFile string `yaml:"closed_channels_file,omitempty"`
+
+	// ClnSocket is the path to a live CLN node's `lightning-rpc` unix
+	// socket.
+	ClnSocket string `yaml:"cln_socket,omitempty"`
+
+	// LndAddress, LndTlsCert and LndMacaroon point at a live LND node's
+	// grpc interface. LndStartTime bounds ListForwards to events at or
+	// after that unix time, so repeated ingests don't refetch the node's
+	// entire forwarding history.
+	LndAddress   string  `yaml:"lnd_address,omitempty"`
+	LndTlsCert   string  `yaml:"lnd_tls_cert,omitempty"`
+	LndMacaroon  string  `yaml:"lnd_macaroon,omitempty"`
+	LndStartTime float64 `yaml:"lnd_start_time,omitempty"`
+}
+
+// RoutingPeerConfig is one peer in a PeerGroupConfig, identified by pubkey.
+type RoutingPeerConfig struct {
+	Name   string `yaml:"name"`
+	Pubkey string `yaml:"pubkey"`
+}
+
+// PeerGroupConfig is a named set of "interesting peers" lsp-node-stats
+// should report routed volume to/from as its own stats block.
+type PeerGroupConfig struct {
+	Name  string              `yaml:"name"`
+	Peers []RoutingPeerConfig `yaml:"peers"`
+}
+
+type Config struct {
+	ChannelOpen ChannelOpenConfig `yaml:"channel_open"`
+	Nodes       []NodeConfig      `yaml:"nodes"`
+	PeerGroups  []PeerGroupConfig `yaml:"peer_groups"`
+}
+
+// Default returns the config matching lsp-node-stats' original hardcoded
+// single-node setup: the breezc node and its dumps, the original
+// channel-open threshold, and the original routing peers. It also sets a
+// one-hour funding lookup window, which the original setup did not have, so
+// forwards within an hour of a channel's funding also count as opens.
+func Default() *Config {
+	return &Config{
+		ChannelOpen: ChannelOpenConfig{
+			MinPpm:               3999,
+			MinOutMsat:           500000,
+			FundingWindowSeconds: 3600,
+		},
+		Nodes: []NodeConfig{
+			{
+				Name:               "breezc",
+				Pubkey:             "02c811e575be2df47d8b48dab3d3f1c9b0f6e16d0d40b5ed78253308fc2bd7170d",
+				ForwardsFile:       "breezc-listforwards-settled-2024-05-06.json.gz",
+				ChannelsFile:       "breezc-listpeerchannels-2024-05-06.json.gz",
+				ClosedChannelsFile: "breezc-listclosedchannels-2024-05-06.json.gz",
+			},
+		},
+		PeerGroups: []PeerGroupConfig{
+			{
+				Name: "routing",
+				Peers: []RoutingPeerConfig{
+					{Name: "BreezR", Pubkey: "02442d4249f9a93464aaf8cd8d522faa869356707b5f1537a8d6def2af50058c5b"},
+					{Name: "Breez", Pubkey: "031015a7839468a3c266d662d5bb21ea4cea24226936e2864a7ca4f2c3939836e0"},
+				},
+			},
+		},
+	}
+}
+
+// Load reads and parses the YAML config file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := Default()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+	"github.com/JssDWt/lsp-node-stats/store"
+)
+
+// Ingest pulls forwards and channel snapshots for name from client and
+// incrementally upserts them into s. Forwards already present are left
+// untouched; channel snapshots are replaced with the latest state.
+func Ingest(s *store.Store, client nodeclient.NodeClient, name string) error {
+	forwards, err := client.ListForwards()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	inserted, err := s.UpsertForwards(name, forwards)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Printf("%s: ingested %d new forwards (of %d in dump)\n", name, inserted, len(forwards))
+
+	channels, err := client.ListChannels()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if err := s.UpsertChannels(name, channels, false); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Printf("%s: upserted %d open channels\n", name, len(channels))
+
+	closedChannels, err := client.ListClosedChannels()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if err := s.UpsertChannels(name, closedChannels, true); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	fmt.Printf("%s: upserted %d closed channels\n", name, len(closedChannels))
+
+	return nil
+}
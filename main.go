@@ -1,162 +1,89 @@
 package main
 
 import (
-	"compress/gzip"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"time"
-)
-
-type ListForwardsResp struct {
-	Forwards []*Forward `json:"forwards"`
-}
-type Forward struct {
-	InChannel    string  `json:"in_channel"`
-	OutChannel   string  `json:"out_channel"`
-	FeeMsat      uint64  `json:"fee_msat"`
-	OutMsat      uint64  `json:"out_msat"`
-	ReceivedTime float64 `json:"received_time"`
-}
 
-type ListPeerChannelsResp struct {
-	Channels []*Channel `json:"channels"`
-}
-type ListClosedChannelsResp struct {
-	Channels []*Channel `json:"closedchannels"`
-}
-type Channel struct {
-	ShortChannelId string `json:"short_channel_id"`
-	Alias          *Alias `json:"alias"`
-	Peer           string `json:"peer_id"`
-}
-
-type Alias struct {
-	LocalAlias  string `json:"local"`
-	RemoteAlias string `json:"remote"`
-}
-
-const (
-	month                    string = "2024-04"
-	BreezcChannelsFile       string = "breezc-listpeerchannels-2024-05-06.json.gz"
-	BreezcClosedChannelsFile string = "breezc-listclosedchannels-2024-05-06.json.gz"
-	BreezcForwardsFile       string = "breezc-listforwards-settled-2024-05-06.json.gz"
+	"github.com/JssDWt/lsp-node-stats/config"
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+	"github.com/JssDWt/lsp-node-stats/report"
+	"github.com/JssDWt/lsp-node-stats/server"
+	"github.com/JssDWt/lsp-node-stats/store"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/macaroons"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"gopkg.in/macaroon.v2"
 )
 
-type interestingPeer struct {
-	name   string
-	pubkey string
-}
-
-var routingNodes []*interestingPeer = []*interestingPeer{
-	{
-		name:   "BreezR",
-		pubkey: "02442d4249f9a93464aaf8cd8d522faa869356707b5f1537a8d6def2af50058c5b",
-	},
-	{
-		name:   "Breez",
-		pubkey: "031015a7839468a3c266d662d5bb21ea4cea24226936e2864a7ca4f2c3939836e0",
-	},
-}
+const month string = "2024-04"
 
-type lspNodeData struct {
-	name              string
-	pubkey            string
-	forwards          []*Forward
-	channels          []*Channel
-	channelPeerLookup map[string]string
-}
+// maxConcurrentIngests bounds how many nodes are ingested at once, so a
+// large fleet of LSPs doesn't exhaust file descriptors or overwhelm a node's
+// RPC server.
+const maxConcurrentIngests int = 4
 
-func initializeNodes() (*lspNodeData, error) {
-	var err error
-	breezc := &lspNodeData{
-		name:   "breezc",
-		pubkey: "02c811e575be2df47d8b48dab3d3f1c9b0f6e16d0d40b5ed78253308fc2bd7170d",
-	}
-	breezc.forwards, err = readForwards(BreezcForwardsFile)
-	if err != nil {
-		return nil, fmt.Errorf("breezc: %w", err)
-	}
-	breezc.channels, err = readChannels(BreezcChannelsFile)
-	if err != nil {
-		return nil, fmt.Errorf("breezc: %w", err)
-	}
-	cc, err := readClosedChannels(BreezcClosedChannelsFile)
-	if err != nil {
-		return nil, fmt.Errorf("breezc: %w", err)
-	}
-	breezc.channels = append(breezc.channels, cc...)
-	breezc.channelPeerLookup = make(map[string]string)
-	for _, channel := range breezc.channels {
-		breezc.channelPeerLookup[channel.ShortChannelId] = channel.Peer
-		breezc.channelPeerLookup[channel.Alias.LocalAlias] = channel.Peer
-	}
+const DefaultDbFile string = "lsp-node-stats.db"
+const DefaultAddr string = ":9090"
+const DefaultConfigFile string = "lsp-node-stats.yaml"
 
-	return breezc, nil
-}
-
-func readForwards(fileName string) ([]*Forward, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open forwards file: %w", err)
-	}
-
-	reader, err := gzip.NewReader(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-
-	var forwards ListForwardsResp
-	err = json.NewDecoder(reader).Decode(&forwards)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode forwards json: %w", err)
-	}
-
-	return forwards.Forwards, nil
-}
-
-func readChannels(fileName string) ([]*Channel, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open channels file: %w", err)
-	}
-
-	reader, err := gzip.NewReader(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+func main() {
+	dbFile := DefaultDbFile
+	configFile := DefaultConfigFile
+	ingest := false
+	serve := false
+	granularity := report.Granularity("")
+	for _, arg := range os.Args[1:] {
+		switch {
+		case arg == "--ingest":
+			ingest = true
+		case arg == "--serve":
+			serve = true
+		case strings.HasPrefix(arg, "--bucket="):
+			granularity = report.Granularity(strings.TrimPrefix(arg, "--bucket="))
+		case strings.HasPrefix(arg, "--config="):
+			configFile = strings.TrimPrefix(arg, "--config=")
+		}
 	}
 
-	var channels ListPeerChannelsResp
-	err = json.NewDecoder(reader).Decode(&channels)
+	cfg, err := config.Load(configFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode channels json: %w", err)
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("failed to load config: %v", err)
+			os.Exit(1)
+		}
+		cfg = config.Default()
 	}
 
-	return channels.Channels, nil
-}
-
-func readClosedChannels(fileName string) ([]*Channel, error) {
-	file, err := os.Open(fileName)
+	s, err := store.NewStore(dbFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open closed channels file: %w", err)
+		fmt.Printf("failed to open store: %v", err)
+		os.Exit(1)
 	}
+	defer s.Close()
 
-	reader, err := gzip.NewReader(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	if ingest {
+		if err := ingestAll(s, cfg.Nodes); err != nil {
+			fmt.Printf("failed to ingest: %v", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	var channels ListClosedChannelsResp
-	err = json.NewDecoder(reader).Decode(&channels)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode closed channels json: %w", err)
+	if serve {
+		srv := server.New(s, cfg)
+		fmt.Printf("listening on %s\n", DefaultAddr)
+		if err := srv.ListenAndServe(DefaultAddr); err != nil {
+			fmt.Printf("server failed: %v", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	return channels.Channels, nil
-}
-
-func main() {
 	start, err := time.ParseInLocation("2006-01", month, time.Local)
 	if err != nil {
 		fmt.Printf("failed to parse month: %v", err)
@@ -165,103 +92,151 @@ func main() {
 	end := AddMonth(start, 1)
 	fmt.Printf("start: %v\n", start)
 	fmt.Printf("end:   %v\n", end)
-	fmt.Printf("DID YOU MAKE SURE THE CHANNELS AND FORWARDS ARE UP-TO-DATE?\n")
 
-	breezc, err := initializeNodes()
-	if err != nil {
-		fmt.Printf("failed to initialize nodes: %v", err)
-		os.Exit(1)
-	}
+	peerGroups := report.PeerGroupsFromConfig(cfg)
 
-	fmt.Println()
-	fmt.Println()
-	fmt.Println("*********************************************************")
-	fmt.Printf("*****************  Report for %s   *****************\n", month)
-	fmt.Println("*********************************************************")
-	fmt.Println()
-	fmt.Println()
+	for _, node := range cfg.Nodes {
+		r, err := report.Load(s, node.Name, float64(start.Unix()), float64(end.Unix()), granularity, peerGroups, cfg)
+		if err != nil {
+			fmt.Printf("failed to get lsp stats for %s: %v", node.Name, err)
+			os.Exit(1)
+		}
 
-	err = lsp_stats(float64(start.Unix()), float64(end.Unix()), breezc, routingNodes)
-	if err != nil {
-		fmt.Printf("failed to get lsp stats for %s: %v", breezc.name, err)
-		os.Exit(1)
-	}
-}
+		fmt.Println()
+		fmt.Println()
+		fmt.Println("*********************************************************")
+		fmt.Printf("*****************  Report for %s (%s)   *****************\n", node.Name, month)
+		fmt.Println("*********************************************************")
+		fmt.Println()
+		fmt.Println()
+		printReport(r)
+	}
+
+	if len(cfg.Nodes) > 1 {
+		r, err := report.LoadCombined(s, cfg.Nodes, float64(start.Unix()), float64(end.Unix()), granularity, peerGroups, cfg)
+		if err != nil {
+			fmt.Printf("failed to get combined lsp stats: %v", err)
+			os.Exit(1)
+		}
 
-type routingStats struct {
-	amountMsat uint64
-	feeMsat    uint64
-	count      uint64
+		fmt.Println()
+		fmt.Println()
+		fmt.Println("*********************************************************")
+		fmt.Printf("*****************  Combined report (%s)   *****************\n", month)
+		fmt.Println("*********************************************************")
+		fmt.Println()
+		fmt.Println()
+		printReport(r)
+	}
+}
+
+// ingestAll ingests every configured node's forwards and channel snapshots
+// into s, running up to maxConcurrentIngests nodes at once.
+func ingestAll(s *store.Store, nodes []config.NodeConfig) error {
+	sem := make(chan struct{}, maxConcurrentIngests)
+	errs := make(chan error, len(nodes))
+	var wg sync.WaitGroup
+
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node config.NodeConfig) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			client, err := nodeClientFor(node)
+			if err != nil {
+				errs <- fmt.Errorf("%s: %w", node.Name, err)
+				return
+			}
+			errs <- Ingest(s, client, node.Name)
+		}(node)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-type lspStats struct {
-	amountMsat               uint64
-	feeMsat                  uint64
-	count                    uint64
-	amountMsatExcludingOpens uint64
-	feeMsatExcludingOpens    uint64
-	countExcludingOpens      uint64
+// nodeClientFor picks node's node client based on which connection fields
+// its config sets: a live CLN node over its RPC socket, a live LND node over
+// grpc, or file dumps if neither RPC field is set.
+func nodeClientFor(node config.NodeConfig) (nodeclient.NodeClient, error) {
+	switch {
+	case node.ClnSocket != "":
+		return nodeclient.NewClnClient(node.ClnSocket), nil
+	case node.LndAddress != "":
+		conn, err := dialLnd(node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial lnd: %w", err)
+		}
+		return nodeclient.NewLndClient(lnrpc.NewLightningClient(conn), node.LndStartTime), nil
+	default:
+		return nodeclient.NewFileClient(node.ForwardsFile, node.ChannelsFile, node.ClosedChannelsFile), nil
+	}
 }
 
-func lsp_stats(start, end float64, node *lspNodeData, routingPeers []*interestingPeer) error {
-	totalstats := &lspStats{}
-	routingstats := &routingStats{}
-	routingLookup := make(map[string]bool)
-	for _, routingPeer := range routingPeers {
-		routingLookup[routingPeer.pubkey] = true
+// dialLnd opens a grpc connection to node's LND address, authenticated the
+// way every lnrpc consumer is: TLS cert plus a macaroon carried as
+// per-RPC credentials.
+func dialLnd(node config.NodeConfig) (*grpc.ClientConn, error) {
+	creds, err := credentials.NewClientTLSFromFile(node.LndTlsCert, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls cert: %w", err)
 	}
 
-	for _, forward := range node.forwards {
-		if forward.ReceivedTime < start || forward.ReceivedTime >= end {
-			continue
-		}
-
-		totalstats.amountMsat += forward.OutMsat
-		totalstats.count++
-		totalstats.feeMsat += forward.FeeMsat
-		if (forward.FeeMsat*1000000)/forward.OutMsat >= 3999 && forward.OutMsat >= 500000 {
-		} else {
-			totalstats.amountMsatExcludingOpens += forward.OutMsat
-			totalstats.countExcludingOpens++
-			totalstats.feeMsatExcludingOpens += forward.FeeMsat
-		}
-
-		inPeerPubkey, ok := node.channelPeerLookup[forward.InChannel]
-		if !ok {
-			return fmt.Errorf("channel '%s' was not in channelPeerLookup", forward.InChannel)
-		}
-		outPeerPubkey, ok := node.channelPeerLookup[forward.OutChannel]
-		if !ok {
-			return fmt.Errorf("channel '%s' was not in channelPeerLookup", forward.OutChannel)
-		}
-		inRouter := routingLookup[inPeerPubkey]
-		outRouter := routingLookup[outPeerPubkey]
-		if inRouter && outRouter {
-			routingstats.amountMsat += forward.OutMsat
-			routingstats.count++
-			routingstats.feeMsat += forward.FeeMsat
-		}
+	macBytes, err := os.ReadFile(node.LndMacaroon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macaroon: %w", err)
 	}
-
-	var routingnames []string
-	for _, p := range routingPeers {
-		routingnames = append(routingnames, p.name)
+	mac := &macaroon.Macaroon{}
+	if err := mac.UnmarshalBinary(macBytes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal macaroon: %w", err)
 	}
+	macCred, err := macaroons.NewMacaroonCredential(mac)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build macaroon credential: %w", err)
+	}
+
+	return grpc.NewClient(node.LndAddress,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(macCred),
+	)
+}
 
+func printReport(r *report.Report) {
 	fmt.Println("*********************************************************")
-	fmt.Printf("LSP node stats - %s\n", node.name)
+	fmt.Printf("LSP node stats - %s\n", r.Node)
 	fmt.Println("---------------------------------------------------------")
-	fmt.Println("Totals all routing")
+	fmt.Println("Totals all routing, per bucket")
 	fmt.Println("Includes all forwards, but fees for probable channel opens are excluded.")
-	fmt.Println("count,amount_msat,fee_msat,count_excluding_opens,amount_msat_excluding_opens,fee_msat_excluding_opens")
-	fmt.Printf("%d,%d,%d,%d,%d,%d\n", totalstats.count, totalstats.amountMsat, totalstats.feeMsat, totalstats.countExcludingOpens, totalstats.amountMsatExcludingOpens, totalstats.feeMsatExcludingOpens)
+	fmt.Println("bucket_start,count,amount_msat,fee_msat,count_excluding_opens,amount_msat_excluding_opens,fee_msat_excluding_opens")
+	for _, b := range r.Series {
+		fmt.Printf("%d,%d,%d,%d,%d,%d,%d\n", int64(b.Start), b.Totals.Count, b.Totals.AmountMsat, b.Totals.FeeMsat, b.Totals.CountExcludingOpens, b.Totals.AmountMsatExcludingOpens, b.Totals.FeeMsatExcludingOpens)
+	}
+	for i, group := range r.PeerGroups {
+		fmt.Println("---------------------------------------------------------")
+		fmt.Printf("Routing to/from only peer group '%s' ('%s'), per bucket\n", group.Name, strings.Join(group.Peers, ", "))
+		fmt.Println("bucket_start,count,amount_msat,fee_msat")
+		for _, b := range r.Series {
+			routing := b.PeerGroups[i].Routing
+			fmt.Printf("%d,%d,%d,%d\n", int64(b.Start), routing.Count, routing.AmountMsat, routing.FeeMsat)
+		}
+	}
 	fmt.Println("---------------------------------------------------------")
-	fmt.Printf("Routing to/from only routing nodes '%s'\n", strings.Join(routingnames, ", "))
-	fmt.Println("count,amount_msat,fee_msat")
-	fmt.Printf("%d,%d,%d\n", routingstats.count, routingstats.amountMsat, routingstats.feeMsat)
+	fmt.Println("Channel-open classifications (for comparing heuristics)")
+	fmt.Println("classifier,count")
+	for _, name := range []string{"threshold", "funding_lookup", "combined"} {
+		fmt.Printf("%s,%d\n", name, r.ChannelOpenCounts[name])
+	}
 	fmt.Println("---------------------------------------------------------")
 	fmt.Println("*********************************************************")
-	return nil
 }
 
 func AddMonth(t time.Time, m int) time.Time {
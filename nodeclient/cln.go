@@ -0,0 +1,110 @@
+package nodeclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// ClnClient implements NodeClient against a live Core Lightning node over
+// its native JSON-RPC interface (the `lightning-rpc` unix socket every CLN
+// node exposes), rather than the grpc plugin: CLN only ships Rust bindings
+// for that, with no published Go client.
+//
+// listforwards/listpeerchannels/listclosedchannels return exactly the JSON
+// shape FileClient replays from disk, so ClnClient reuses the same response
+// types and toChannels helper.
+type ClnClient struct {
+	socketPath string
+}
+
+// NewClnClient builds a ClnClient that dials socketPath for every call.
+// socketPath is CLN's `lightning-rpc` file, typically
+// ~/.lightning/<network>/lightning-rpc.
+func NewClnClient(socketPath string) *ClnClient {
+	return &ClnClient{socketPath: socketPath}
+}
+
+func (c *ClnClient) ListForwards() ([]*Forward, error) {
+	var resp listForwardsResp
+	if err := c.call("listforwards", map[string]string{"status": "settled"}, &resp); err != nil {
+		return nil, fmt.Errorf("listforwards failed: %w", err)
+	}
+
+	forwards := make([]*Forward, 0, len(resp.Forwards))
+	for _, f := range resp.Forwards {
+		forwards = append(forwards, &Forward{
+			InChannel:    f.InChannel,
+			OutChannel:   f.OutChannel,
+			FeeMsat:      f.FeeMsat,
+			OutMsat:      f.OutMsat,
+			ReceivedTime: f.ReceivedTime,
+		})
+	}
+
+	return forwards, nil
+}
+
+func (c *ClnClient) ListChannels() ([]*Channel, error) {
+	var resp listPeerChannelsResp
+	if err := c.call("listpeerchannels", nil, &resp); err != nil {
+		return nil, fmt.Errorf("listpeerchannels failed: %w", err)
+	}
+
+	return toChannels(resp.Channels), nil
+}
+
+func (c *ClnClient) ListClosedChannels() ([]*Channel, error) {
+	var resp listClosedChannelsResp
+	if err := c.call("listclosedchannels", nil, &resp); err != nil {
+		return nil, fmt.Errorf("listclosedchannels failed: %w", err)
+	}
+
+	return toChannels(resp.Channels), nil
+}
+
+type clnRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type clnError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type clnResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *clnError       `json:"error"`
+}
+
+// call issues a single JSON-RPC request over a fresh connection to
+// socketPath and decodes its result into v.
+func (c *ClnClient) call(method string, params, v any) error {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s: %w", c.socketPath, err)
+	}
+	defer conn.Close()
+
+	req := clnRequest{JSONRPC: "2.0", ID: method, Method: method, Params: params}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	var resp clnResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read %s response: %w", method, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s returned error %d: %s", method, resp.Error.Code, resp.Error.Message)
+	}
+
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package nodeclient
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type listForwardsResp struct {
+	Forwards []*fileForward `json:"forwards"`
+}
+type fileForward struct {
+	InChannel    string  `json:"in_channel"`
+	OutChannel   string  `json:"out_channel"`
+	FeeMsat      uint64  `json:"fee_msat"`
+	OutMsat      uint64  `json:"out_msat"`
+	ReceivedTime float64 `json:"received_time"`
+}
+
+type listPeerChannelsResp struct {
+	Channels []*fileChannel `json:"channels"`
+}
+type listClosedChannelsResp struct {
+	Channels []*fileChannel `json:"closedchannels"`
+}
+type fileChannel struct {
+	ShortChannelId string            `json:"short_channel_id"`
+	Alias          *fileAlias        `json:"alias"`
+	Peer           string            `json:"peer_id"`
+	StateChanges   []fileStateChange `json:"state_changes"`
+}
+type fileAlias struct {
+	LocalAlias  string `json:"local"`
+	RemoteAlias string `json:"remote"`
+}
+type fileStateChange struct {
+	Timestamp string `json:"timestamp"`
+	NewState  string `json:"new_state"`
+}
+
+// FileClient implements NodeClient by reading gzipped `listforwards`,
+// `listpeerchannels` and `listclosedchannels` dumps from disk. It exists for
+// backwards compatibility with reports generated before lsp-node-stats could
+// talk to a live node.
+type FileClient struct {
+	ForwardsFile       string
+	ChannelsFile       string
+	ClosedChannelsFile string
+}
+
+func NewFileClient(forwardsFile, channelsFile, closedChannelsFile string) *FileClient {
+	return &FileClient{
+		ForwardsFile:       forwardsFile,
+		ChannelsFile:       channelsFile,
+		ClosedChannelsFile: closedChannelsFile,
+	}
+}
+
+func (c *FileClient) ListForwards() ([]*Forward, error) {
+	var resp listForwardsResp
+	if err := readGzipJson(c.ForwardsFile, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read forwards file: %w", err)
+	}
+
+	forwards := make([]*Forward, 0, len(resp.Forwards))
+	for _, f := range resp.Forwards {
+		forwards = append(forwards, &Forward{
+			InChannel:    f.InChannel,
+			OutChannel:   f.OutChannel,
+			FeeMsat:      f.FeeMsat,
+			OutMsat:      f.OutMsat,
+			ReceivedTime: f.ReceivedTime,
+		})
+	}
+
+	return forwards, nil
+}
+
+func (c *FileClient) ListChannels() ([]*Channel, error) {
+	var resp listPeerChannelsResp
+	if err := readGzipJson(c.ChannelsFile, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read channels file: %w", err)
+	}
+
+	return toChannels(resp.Channels), nil
+}
+
+func (c *FileClient) ListClosedChannels() ([]*Channel, error) {
+	var resp listClosedChannelsResp
+	if err := readGzipJson(c.ClosedChannelsFile, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read closed channels file: %w", err)
+	}
+
+	return toChannels(resp.Channels), nil
+}
+
+func toChannels(in []*fileChannel) []*Channel {
+	channels := make([]*Channel, 0, len(in))
+	for _, c := range in {
+		channel := &Channel{
+			ShortChannelId: c.ShortChannelId,
+			Peer:           c.Peer,
+			FundingTime:    fundingTime(c.StateChanges),
+		}
+		if c.Alias != nil {
+			channel.LocalAlias = c.Alias.LocalAlias
+			channel.RemoteAlias = c.Alias.RemoteAlias
+		}
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// fundingTime returns the unix time a channel's funding transaction
+// confirmed enough to leave CHANNELD_AWAITING_LOCKIN, which CLN records as a
+// CHANNELD_NORMAL entry in state_changes. Returns 0 if the dump doesn't
+// carry state_changes, or the transition was never recorded (e.g. channels
+// opened before CLN started tracking it).
+func fundingTime(changes []fileStateChange) float64 {
+	for _, change := range changes {
+		if change.NewState != "CHANNELD_NORMAL" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, change.Timestamp)
+		if err != nil {
+			continue
+		}
+		return float64(t.Unix())
+	}
+	return 0
+}
+
+func readGzipJson(fileName string, v any) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", fileName, err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader for %s: %w", fileName, err)
+	}
+
+	if err := json.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", fileName, err)
+	}
+
+	return nil
+}
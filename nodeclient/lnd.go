@@ -0,0 +1,100 @@
+package nodeclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+)
+
+// lndForwardingHistoryPageSize is the page size ListForwards requests at a
+// time; a page shorter than this signals the last page.
+const lndForwardingHistoryPageSize uint32 = 1 << 16
+
+// LndClient implements NodeClient against a live LND node over its gRPC
+// interface.
+type LndClient struct {
+	rpc lnrpc.LightningClient
+	// startTime bounds ForwardingHistory to events at or after this unix
+	// time, so repeated ingests don't refetch the node's entire history.
+	startTime uint64
+}
+
+// NewLndClient wraps an already-dialed LND gRPC connection, authenticated
+// the same way any other lnrpc consumer would (TLS cert + macaroon).
+// startTime bounds ListForwards to events at or after that unix time; pass 0
+// to fetch the node's whole history.
+func NewLndClient(conn lnrpc.LightningClient, startTime float64) *LndClient {
+	return &LndClient{rpc: conn, startTime: uint64(startTime)}
+}
+
+// ListForwards pages through ForwardingHistory via IndexOffset until a page
+// comes back shorter than requested, since a single call is capped at
+// NumMaxEvents and a long-lived node can have far more forwards than that.
+func (c *LndClient) ListForwards() ([]*Forward, error) {
+	var forwards []*Forward
+	var offset uint32
+	for {
+		resp, err := c.rpc.ForwardingHistory(context.Background(), &lnrpc.ForwardingHistoryRequest{
+			StartTime:    c.startTime,
+			IndexOffset:  offset,
+			NumMaxEvents: lndForwardingHistoryPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("forwardinghistory failed: %w", err)
+		}
+
+		for _, f := range resp.ForwardingEvents {
+			forwards = append(forwards, &Forward{
+				InChannel:    fmt.Sprintf("%d", f.ChanIdIn),
+				OutChannel:   fmt.Sprintf("%d", f.ChanIdOut),
+				FeeMsat:      f.FeeMsat,
+				OutMsat:      f.AmtOutMsat,
+				ReceivedTime: float64(f.Timestamp),
+			})
+		}
+
+		if len(resp.ForwardingEvents) < int(lndForwardingHistoryPageSize) {
+			return forwards, nil
+		}
+		offset = resp.LastOffsetIndex
+	}
+}
+
+func (c *LndClient) ListChannels() ([]*Channel, error) {
+	resp, err := c.rpc.ListChannels(context.Background(), &lnrpc.ListChannelsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listchannels failed: %w", err)
+	}
+
+	channels := make([]*Channel, 0, len(resp.Channels))
+	for _, ch := range resp.Channels {
+		// lnrpc's Channel doesn't expose the funding transaction's
+		// confirmation time, only a chan_id encoding its block height, so
+		// FundingTime is left unset here; a funding-time lookup classifier
+		// has no signal for LND-sourced data until that's wired up.
+		channels = append(channels, &Channel{
+			ShortChannelId: fmt.Sprintf("%d", ch.ChanId),
+			Peer:           ch.RemotePubkey,
+		})
+	}
+
+	return channels, nil
+}
+
+func (c *LndClient) ListClosedChannels() ([]*Channel, error) {
+	resp, err := c.rpc.ClosedChannels(context.Background(), &lnrpc.ClosedChannelsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("closedchannels failed: %w", err)
+	}
+
+	channels := make([]*Channel, 0, len(resp.Channels))
+	for _, ch := range resp.Channels {
+		channels = append(channels, &Channel{
+			ShortChannelId: fmt.Sprintf("%d", ch.ChanId),
+			Peer:           ch.RemotePubkey,
+		})
+	}
+
+	return channels, nil
+}
@@ -0,0 +1,35 @@
+// Package nodeclient abstracts where forwarding history and channel
+// snapshots come from, so lsp-node-stats can either replay old gzip dumps or
+// talk to a live CLN or LND node.
+package nodeclient
+
+// Forward is a single settled forward, independent of which node reported
+// it.
+type Forward struct {
+	InChannel    string
+	OutChannel   string
+	FeeMsat      uint64
+	OutMsat      uint64
+	ReceivedTime float64
+}
+
+// Channel is a single channel (open or closed), independent of which node
+// reported it.
+type Channel struct {
+	ShortChannelId string
+	LocalAlias     string
+	RemoteAlias    string
+	Peer           string
+	// FundingTime is the unix time the channel's funding transaction
+	// confirmed, if the node client can report it. It's 0 when unknown.
+	FundingTime float64
+}
+
+// NodeClient is implemented by anything that can supply the forwarding
+// history and channel snapshots lsp-node-stats needs to build a report: a
+// live CLN or LND node, or a directory of gzipped RPC dumps.
+type NodeClient interface {
+	ListForwards() ([]*Forward, error)
+	ListChannels() ([]*Channel, error)
+	ListClosedChannels() ([]*Channel, error)
+}
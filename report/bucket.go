@@ -0,0 +1,61 @@
+package report
+
+import "time"
+
+// Granularity controls how Compute buckets forwards within [start, end).
+// The zero value buckets the whole range into a single Bucket, matching the
+// original monthly-total behavior.
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+	GranularityWeek Granularity = "week"
+)
+
+// PeerTotals is one routing peer's share of a Bucket, keyed by the out peer:
+// the peer the node forwarded the payment to.
+type PeerTotals struct {
+	Peer       string `json:"peer"`
+	Count      uint64 `json:"count"`
+	AmountMsat uint64 `json:"amount_msat"`
+	FeeMsat    uint64 `json:"fee_msat"`
+}
+
+// Bucket carries the same counters as Report.Totals/PeerGroups, scoped to a
+// single time bucket starting at Start.
+type Bucket struct {
+	Start         float64           `json:"bucket_start"`
+	Totals        Totals            `json:"totals"`
+	PeerGroups    []PeerGroupTotals `json:"peer_groups"`
+	PeerBreakdown []PeerTotals      `json:"peer_breakdown"`
+}
+
+// bucketStart truncates a unix timestamp down to the start of the bucket it
+// falls in, in Local time. The zero Granularity is treated as "no
+// bucketing" and always returns start, so callers get a single bucket
+// covering the whole report range.
+func bucketStart(ts float64, granularity Granularity, start float64) float64 {
+	if granularity == "" {
+		return start
+	}
+
+	t := time.Unix(int64(ts), 0).In(time.Local)
+	switch granularity {
+	case GranularityHour:
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+	case GranularityDay:
+		t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	case GranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		t = day.AddDate(0, 0, -(weekday - 1))
+	default:
+		return start
+	}
+
+	return float64(t.Unix())
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"github.com/JssDWt/lsp-node-stats/config"
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+)
+
+// ChannelOpenClassifier decides whether a forward is probably a channel
+// open rather than a routed payment, so its fee can be excluded from
+// routing totals.
+type ChannelOpenClassifier interface {
+	IsChannelOpen(forward *nodeclient.Forward) bool
+}
+
+// ThresholdClassifier is lsp-node-stats' original heuristic: a forward
+// counts as a channel open once its effective ppm fee and out amount both
+// clear a threshold, with an optional upper ppm bound to rule out payments
+// that just happen to pay an unusually high fee.
+type ThresholdClassifier struct {
+	MinPpm     uint64
+	MaxPpm     *uint64
+	MinOutMsat uint64
+}
+
+func (c ThresholdClassifier) IsChannelOpen(forward *nodeclient.Forward) bool {
+	if forward.OutMsat < c.MinOutMsat {
+		return false
+	}
+	if forward.OutMsat == 0 {
+		return false
+	}
+
+	ppm := (forward.FeeMsat * 1000000) / forward.OutMsat
+	if ppm < c.MinPpm {
+		return false
+	}
+	if c.MaxPpm != nil && ppm > *c.MaxPpm {
+		return false
+	}
+
+	return true
+}
+
+// FundingLookupClassifier treats a forward as a channel open if its
+// out_channel's funding transaction confirmed within Window seconds of the
+// forward's received_time.
+type FundingLookupClassifier struct {
+	FundingTimes map[string]float64
+	Window       float64
+}
+
+func (c FundingLookupClassifier) IsChannelOpen(forward *nodeclient.Forward) bool {
+	fundingTime, ok := c.FundingTimes[forward.OutChannel]
+	if !ok {
+		return false
+	}
+
+	diff := forward.ReceivedTime - fundingTime
+	if diff < 0 {
+		diff = -diff
+	}
+
+	return diff <= c.Window
+}
+
+// CombinedClassifier classifies a forward as a channel open if any of its
+// classifiers do.
+type CombinedClassifier struct {
+	Classifiers []ChannelOpenClassifier
+}
+
+func (c CombinedClassifier) IsChannelOpen(forward *nodeclient.Forward) bool {
+	for _, classifier := range c.Classifiers {
+		if classifier.IsChannelOpen(forward) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClassifiers builds lsp-node-stats' channel-open classifiers from cfg.
+// The combined classifier is returned as the primary one, used to decide
+// the *ExcludingOpens totals; all three are returned by name so a Report can
+// carry their counts as a diagnostic.
+func NewClassifiers(cfg *config.Config, fundingTimes map[string]float64) (primary ChannelOpenClassifier, classifiers map[string]ChannelOpenClassifier) {
+	threshold := ThresholdClassifier{
+		MinPpm:     cfg.ChannelOpen.MinPpm,
+		MaxPpm:     cfg.ChannelOpen.MaxPpm,
+		MinOutMsat: cfg.ChannelOpen.MinOutMsat,
+	}
+	lookup := FundingLookupClassifier{
+		FundingTimes: fundingTimes,
+		Window:       cfg.ChannelOpen.FundingWindowSeconds,
+	}
+	combined := CombinedClassifier{
+		Classifiers: []ChannelOpenClassifier{threshold, lookup},
+	}
+
+	classifiers = map[string]ChannelOpenClassifier{
+		"threshold":      threshold,
+		"funding_lookup": lookup,
+		"combined":       combined,
+	}
+
+	return combined, classifiers
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+)
+
+func TestThresholdClassifier(t *testing.T) {
+	maxPpm := uint64(500000)
+	classifier := ThresholdClassifier{
+		MinPpm:     100000,
+		MaxPpm:     &maxPpm,
+		MinOutMsat: 1_000_000,
+	}
+
+	cases := []struct {
+		name    string
+		forward *nodeclient.Forward
+		want    bool
+	}{
+		{
+			name:    "below min out msat",
+			forward: &nodeclient.Forward{OutMsat: 999_999, FeeMsat: 500_000},
+			want:    false,
+		},
+		{
+			name:    "below min ppm",
+			forward: &nodeclient.Forward{OutMsat: 1_000_000, FeeMsat: 50_000},
+			want:    false,
+		},
+		{
+			name:    "above max ppm",
+			forward: &nodeclient.Forward{OutMsat: 1_000_000, FeeMsat: 600_000},
+			want:    false,
+		},
+		{
+			name:    "within range",
+			forward: &nodeclient.Forward{OutMsat: 1_000_000, FeeMsat: 200_000},
+			want:    true,
+		},
+	}
+
+	zeroMinClassifier := ThresholdClassifier{MinPpm: 100000}
+	t.Run("zero out msat with zero min_out_msat doesn't panic", func(t *testing.T) {
+		if got := zeroMinClassifier.IsChannelOpen(&nodeclient.Forward{OutMsat: 0, FeeMsat: 0}); got != false {
+			t.Errorf("IsChannelOpen() = %v, want false", got)
+		}
+	})
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := classifier.IsChannelOpen(c.forward); got != c.want {
+				t.Errorf("IsChannelOpen() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFundingLookupClassifier(t *testing.T) {
+	classifier := FundingLookupClassifier{
+		FundingTimes: map[string]float64{"100x1x0": 1000},
+		Window:       60,
+	}
+
+	cases := []struct {
+		name    string
+		forward *nodeclient.Forward
+		want    bool
+	}{
+		{
+			name:    "unknown channel",
+			forward: &nodeclient.Forward{OutChannel: "999x1x0", ReceivedTime: 1000},
+			want:    false,
+		},
+		{
+			name:    "within window after funding",
+			forward: &nodeclient.Forward{OutChannel: "100x1x0", ReceivedTime: 1050},
+			want:    true,
+		},
+		{
+			name:    "within window before funding",
+			forward: &nodeclient.Forward{OutChannel: "100x1x0", ReceivedTime: 950},
+			want:    true,
+		},
+		{
+			name:    "outside window",
+			forward: &nodeclient.Forward{OutChannel: "100x1x0", ReceivedTime: 1100},
+			want:    false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := classifier.IsChannelOpen(c.forward); got != c.want {
+				t.Errorf("IsChannelOpen() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCombinedClassifier(t *testing.T) {
+	always := stubClassifier(true)
+	never := stubClassifier(false)
+
+	cases := []struct {
+		name        string
+		classifiers []ChannelOpenClassifier
+		want        bool
+	}{
+		{name: "none", classifiers: nil, want: false},
+		{name: "all false", classifiers: []ChannelOpenClassifier{never, never}, want: false},
+		{name: "one true", classifiers: []ChannelOpenClassifier{never, always}, want: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			combined := CombinedClassifier{Classifiers: c.classifiers}
+			if got := combined.IsChannelOpen(&nodeclient.Forward{}); got != c.want {
+				t.Errorf("IsChannelOpen() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+type stubClassifier bool
+
+func (s stubClassifier) IsChannelOpen(*nodeclient.Forward) bool {
+	return bool(s)
+}
@@ -0,0 +1,60 @@
+package report
+
+import "github.com/JssDWt/lsp-node-stats/nodeclient"
+
+// CombinedNode is the synthetic node name used for the cross-LSP report
+// produced by merging every configured node's forwards via MergeForwards.
+const CombinedNode string = "combined"
+
+// MergeForwards concatenates each node's forwards and channel-peer lookup
+// into a single set, suitable for Compute-ing a cross-LSP report. ownedPubkeys
+// is the pubkey of every node being merged; forwards that hand a payment off
+// to another owned node are dropped, since that volume is already captured
+// by the receiving node's own forward for the next hop, and counting both
+// would double-count it.
+//
+// Channel IDs are namespaced by their originating node before being merged,
+// since two owned nodes can share the same real short_channel_id (a direct
+// channel between them) while disagreeing on which pubkey is on the other
+// end of it; merging their lookups under the bare scid would let one node's
+// view randomly clobber the other's.
+func MergeForwards(nodeForwards map[string][]*nodeclient.Forward, nodeLookups map[string]map[string]string, ownedPubkeys map[string]bool) ([]*nodeclient.Forward, map[string]string) {
+	mergedLookup := make(map[string]string)
+	var merged []*nodeclient.Forward
+
+	for name, forwards := range nodeForwards {
+		lookup := nodeLookups[name]
+		for _, forward := range forwards {
+			outPeer, ok := lookup[forward.OutChannel]
+			if ok && ownedPubkeys[outPeer] {
+				continue
+			}
+
+			merged = append(merged, &nodeclient.Forward{
+				InChannel:    NamespaceChannel(name, forward.InChannel),
+				OutChannel:   NamespaceChannel(name, forward.OutChannel),
+				FeeMsat:      forward.FeeMsat,
+				OutMsat:      forward.OutMsat,
+				ReceivedTime: forward.ReceivedTime,
+			})
+
+			if inPeer, ok := lookup[forward.InChannel]; ok {
+				mergedLookup[NamespaceChannel(name, forward.InChannel)] = inPeer
+			}
+			if ok {
+				mergedLookup[NamespaceChannel(name, forward.OutChannel)] = outPeer
+			}
+		}
+	}
+
+	return merged, mergedLookup
+}
+
+// NamespaceChannel prefixes channel, a short_channel_id, with node so IDs
+// from different merged nodes can't collide. Callers that build per-node
+// lookups keyed by short_channel_id (e.g. funding times) must namespace
+// their keys the same way before merging, to match the channel IDs
+// MergeForwards produces.
+func NamespaceChannel(node, channel string) string {
+	return node + ":" + channel
+}
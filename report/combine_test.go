@@ -0,0 +1,56 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+)
+
+func TestMergeForwardsDropsHandoffsBetweenOwnedNodes(t *testing.T) {
+	nodeForwards := map[string][]*nodeclient.Forward{
+		"a": {
+			{InChannel: "1x1x0", OutChannel: "2x1x0", OutMsat: 1000, FeeMsat: 1},
+		},
+		"b": {
+			{InChannel: "2x1x0", OutChannel: "3x1x0", OutMsat: 1000, FeeMsat: 1},
+		},
+	}
+	nodeLookups := map[string]map[string]string{
+		"a": {"1x1x0": "peerX", "2x1x0": "pubkeyB"},
+		"b": {"2x1x0": "pubkeyA", "3x1x0": "peerY"},
+	}
+	ownedPubkeys := map[string]bool{"pubkeyA": true, "pubkeyB": true}
+
+	forwards, lookup := MergeForwards(nodeForwards, nodeLookups, ownedPubkeys)
+
+	if len(forwards) != 1 {
+		t.Fatalf("got %d forwards, want 1 (a's handoff to b should be dropped)", len(forwards))
+	}
+	want := NamespaceChannel("b", "3x1x0")
+	if forwards[0].OutChannel != want {
+		t.Errorf("OutChannel = %q, want %q", forwards[0].OutChannel, want)
+	}
+	if lookup[want] != "peerY" {
+		t.Errorf("lookup[%q] = %q, want peerY", want, lookup[want])
+	}
+}
+
+func TestMergeForwardsNamespacesSharedChannelIDs(t *testing.T) {
+	nodeForwards := map[string][]*nodeclient.Forward{
+		"a": {{InChannel: "1x1x0", OutChannel: "5x1x0", OutMsat: 1000}},
+		"b": {{InChannel: "1x1x0", OutChannel: "6x1x0", OutMsat: 1000}},
+	}
+	nodeLookups := map[string]map[string]string{
+		"a": {"1x1x0": "peerOnA", "5x1x0": "peerX"},
+		"b": {"1x1x0": "peerOnB", "6x1x0": "peerY"},
+	}
+
+	_, lookup := MergeForwards(nodeForwards, nodeLookups, nil)
+
+	if got := lookup[NamespaceChannel("a", "1x1x0")]; got != "peerOnA" {
+		t.Errorf("lookup[a:1x1x0] = %q, want peerOnA", got)
+	}
+	if got := lookup[NamespaceChannel("b", "1x1x0")]; got != "peerOnB" {
+		t.Errorf("lookup[b:1x1x0] = %q, want peerOnB", got)
+	}
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/JssDWt/lsp-node-stats/config"
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+	"github.com/JssDWt/lsp-node-stats/store"
+)
+
+// Load fetches node's forwards, channel-peer lookup and funding times from s
+// and computes a Report for [start, end), bucketed by granularity. It's the
+// store-to-Compute plumbing shared by the CLI and the HTTP server.
+func Load(s *store.Store, node string, start, end float64, granularity Granularity, peerGroups []PeerGroup, cfg *config.Config) (*Report, error) {
+	forwards, err := s.Forwards(node, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", node, err)
+	}
+
+	channelPeerLookup, err := s.ChannelPeerLookup(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", node, err)
+	}
+
+	fundingTimes, err := s.FundingTimes(node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", node, err)
+	}
+
+	primary, classifiers := NewClassifiers(cfg, fundingTimes)
+	return Compute(node, start, end, granularity, forwards, channelPeerLookup, peerGroups, primary, classifiers)
+}
+
+// LoadCombined merges every node's forwards and channel-peer lookup into a
+// single cross-LSP report, dropping forwards that hand a payment off from
+// one owned node straight to another so that volume isn't counted once per
+// leg.
+func LoadCombined(s *store.Store, nodes []config.NodeConfig, start, end float64, granularity Granularity, peerGroups []PeerGroup, cfg *config.Config) (*Report, error) {
+	nodeForwards := make(map[string][]*nodeclient.Forward, len(nodes))
+	nodeLookups := make(map[string]map[string]string, len(nodes))
+	ownedPubkeys := make(map[string]bool, len(nodes))
+	fundingTimes := make(map[string]float64)
+
+	for _, node := range nodes {
+		forwards, err := s.Forwards(node.Name, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.Name, err)
+		}
+		lookup, err := s.ChannelPeerLookup(node.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.Name, err)
+		}
+		nodeFundingTimes, err := s.FundingTimes(node.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.Name, err)
+		}
+
+		nodeForwards[node.Name] = forwards
+		nodeLookups[node.Name] = lookup
+		ownedPubkeys[node.Pubkey] = true
+		for scid, t := range nodeFundingTimes {
+			fundingTimes[NamespaceChannel(node.Name, scid)] = t
+		}
+	}
+
+	forwards, channelPeerLookup := MergeForwards(nodeForwards, nodeLookups, ownedPubkeys)
+
+	primary, classifiers := NewClassifiers(cfg, fundingTimes)
+	return Compute(CombinedNode, start, end, granularity, forwards, channelPeerLookup, peerGroups, primary, classifiers)
+}
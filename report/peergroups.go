@@ -0,0 +1,17 @@
+package report
+
+import "github.com/JssDWt/lsp-node-stats/config"
+
+// PeerGroupsFromConfig converts cfg's peer group config into the PeerGroup
+// values Compute expects.
+func PeerGroupsFromConfig(cfg *config.Config) []PeerGroup {
+	groups := make([]PeerGroup, 0, len(cfg.PeerGroups))
+	for _, g := range cfg.PeerGroups {
+		peers := make([]RoutingPeer, 0, len(g.Peers))
+		for _, p := range g.Peers {
+			peers = append(peers, RoutingPeer{Name: p.Name, Pubkey: p.Pubkey})
+		}
+		groups = append(groups, PeerGroup{Name: g.Name, Peers: peers})
+	}
+	return groups
+}
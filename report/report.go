@@ -0,0 +1,185 @@
+// Package report computes lsp-node-stats' forwarding stats into a
+// structured Report, so callers can print it, serve it as JSON, or export it
+// as Prometheus metrics without recomputing anything.
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+)
+
+// RoutingPeer is a peer lsp-node-stats should report routed volume to/from
+// separately, identified by pubkey.
+type RoutingPeer struct {
+	Name   string
+	Pubkey string
+}
+
+type Totals struct {
+	Count                    uint64 `json:"count"`
+	AmountMsat               uint64 `json:"amount_msat"`
+	FeeMsat                  uint64 `json:"fee_msat"`
+	CountExcludingOpens      uint64 `json:"count_excluding_opens"`
+	AmountMsatExcludingOpens uint64 `json:"amount_msat_excluding_opens"`
+	FeeMsatExcludingOpens    uint64 `json:"fee_msat_excluding_opens"`
+}
+
+type RoutingTotals struct {
+	Count      uint64 `json:"count"`
+	AmountMsat uint64 `json:"amount_msat"`
+	FeeMsat    uint64 `json:"fee_msat"`
+}
+
+// PeerGroup is a named set of "interesting peers" Compute should report
+// routed volume to/from as its own stats block, separately from the
+// node's overall totals.
+type PeerGroup struct {
+	Name  string
+	Peers []RoutingPeer
+}
+
+// PeerGroupTotals is one PeerGroup's routing totals in a Report or Bucket.
+type PeerGroupTotals struct {
+	Name    string        `json:"name"`
+	Peers   []string      `json:"peers"`
+	Routing RoutingTotals `json:"routing"`
+}
+
+// Report is the result of running lsp-node-stats over one node's forwards
+// for a [Start, End) window.
+type Report struct {
+	Node              string            `json:"node"`
+	Start             float64           `json:"start"`
+	End               float64           `json:"end"`
+	Totals            Totals            `json:"totals"`
+	PeerGroups        []PeerGroupTotals `json:"peer_groups"`
+	Series            []Bucket          `json:"series"`
+	ChannelOpenCounts map[string]uint64 `json:"channel_open_counts"`
+}
+
+// Compute aggregates forwards in [start, end) into a Report, bucketed by
+// granularity (the zero value yields a single bucket covering the whole
+// range). forwards and channelPeerLookup are expected to already be scoped
+// to the node being reported on.
+//
+// peerGroups is zero or more named sets of "interesting peers"; each gets
+// its own routing stats block in the Report and in every Bucket, covering
+// forwards routed between two peers in the same group.
+//
+// primaryClassifier decides which forwards are excluded from the *ExcludingOpens
+// totals. classifiers is evaluated against every forward purely for the
+// ChannelOpenCounts diagnostic, so operators can compare classifiers against
+// each other without re-running the report.
+func Compute(node string, start, end float64, granularity Granularity, forwards []*nodeclient.Forward, channelPeerLookup map[string]string, peerGroups []PeerGroup, primaryClassifier ChannelOpenClassifier, classifiers map[string]ChannelOpenClassifier) (*Report, error) {
+	r := &Report{
+		Node:              node,
+		Start:             start,
+		End:               end,
+		ChannelOpenCounts: make(map[string]uint64, len(classifiers)),
+	}
+
+	groupLookups := make([]map[string]bool, len(peerGroups))
+	for i, group := range peerGroups {
+		lookup := make(map[string]bool, len(group.Peers))
+		var names []string
+		for _, peer := range group.Peers {
+			lookup[peer.Pubkey] = true
+			names = append(names, peer.Name)
+		}
+		groupLookups[i] = lookup
+		r.PeerGroups = append(r.PeerGroups, PeerGroupTotals{Name: group.Name, Peers: names})
+	}
+
+	buckets := make(map[float64]*Bucket)
+	peerTotals := make(map[float64]map[string]*PeerTotals)
+
+	for _, forward := range forwards {
+		if forward.ReceivedTime < start || forward.ReceivedTime >= end {
+			continue
+		}
+
+		inPeerPubkey, ok := channelPeerLookup[forward.InChannel]
+		if !ok {
+			return nil, fmt.Errorf("channel '%s' was not in channelPeerLookup", forward.InChannel)
+		}
+		outPeerPubkey, ok := channelPeerLookup[forward.OutChannel]
+		if !ok {
+			return nil, fmt.Errorf("channel '%s' was not in channelPeerLookup", forward.OutChannel)
+		}
+
+		for name, classifier := range classifiers {
+			if classifier.IsChannelOpen(forward) {
+				r.ChannelOpenCounts[name]++
+			}
+		}
+
+		r.Totals.AmountMsat += forward.OutMsat
+		r.Totals.Count++
+		r.Totals.FeeMsat += forward.FeeMsat
+		if !primaryClassifier.IsChannelOpen(forward) {
+			r.Totals.AmountMsatExcludingOpens += forward.OutMsat
+			r.Totals.CountExcludingOpens++
+			r.Totals.FeeMsatExcludingOpens += forward.FeeMsat
+		}
+
+		bucketStart := bucketStart(forward.ReceivedTime, granularity, start)
+		bucket, ok := buckets[bucketStart]
+		if !ok {
+			bucket = &Bucket{Start: bucketStart}
+			for _, group := range r.PeerGroups {
+				bucket.PeerGroups = append(bucket.PeerGroups, PeerGroupTotals{Name: group.Name, Peers: group.Peers})
+			}
+			buckets[bucketStart] = bucket
+			peerTotals[bucketStart] = make(map[string]*PeerTotals)
+		}
+
+		bucket.Totals.AmountMsat += forward.OutMsat
+		bucket.Totals.Count++
+		bucket.Totals.FeeMsat += forward.FeeMsat
+		if !primaryClassifier.IsChannelOpen(forward) {
+			bucket.Totals.AmountMsatExcludingOpens += forward.OutMsat
+			bucket.Totals.CountExcludingOpens++
+			bucket.Totals.FeeMsatExcludingOpens += forward.FeeMsat
+		}
+
+		for i, lookup := range groupLookups {
+			if !lookup[inPeerPubkey] || !lookup[outPeerPubkey] {
+				continue
+			}
+			r.PeerGroups[i].Routing.AmountMsat += forward.OutMsat
+			r.PeerGroups[i].Routing.Count++
+			r.PeerGroups[i].Routing.FeeMsat += forward.FeeMsat
+
+			bucket.PeerGroups[i].Routing.AmountMsat += forward.OutMsat
+			bucket.PeerGroups[i].Routing.Count++
+			bucket.PeerGroups[i].Routing.FeeMsat += forward.FeeMsat
+		}
+
+		peer := peerTotals[bucketStart][outPeerPubkey]
+		if peer == nil {
+			peer = &PeerTotals{Peer: outPeerPubkey}
+			peerTotals[bucketStart][outPeerPubkey] = peer
+		}
+		peer.Count++
+		peer.AmountMsat += forward.OutMsat
+		peer.FeeMsat += forward.FeeMsat
+	}
+
+	r.Series = make([]Bucket, 0, len(buckets))
+	for start, bucket := range buckets {
+		for _, peer := range peerTotals[start] {
+			bucket.PeerBreakdown = append(bucket.PeerBreakdown, *peer)
+		}
+		sort.Slice(bucket.PeerBreakdown, func(i, j int) bool {
+			return bucket.PeerBreakdown[i].Peer < bucket.PeerBreakdown[j].Peer
+		})
+		r.Series = append(r.Series, *bucket)
+	}
+	sort.Slice(r.Series, func(i, j int) bool {
+		return r.Series[i].Start < r.Series[j].Start
+	})
+
+	return r, nil
+}
@@ -0,0 +1,112 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+)
+
+func TestComputeTotals(t *testing.T) {
+	forwards := []*nodeclient.Forward{
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 1000, FeeMsat: 10, ReceivedTime: 100},
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 2000, FeeMsat: 20, ReceivedTime: 200},
+		// Outside [start, end), should be ignored.
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 5000, FeeMsat: 50, ReceivedTime: 9000},
+	}
+	lookup := map[string]string{"in1": "peerIn", "out1": "peerOut"}
+
+	classifiers := map[string]ChannelOpenClassifier{"threshold": stubClassifier(false)}
+	r, err := Compute("node", 0, 1000, "", forwards, lookup, nil, stubClassifier(false), classifiers)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	if r.Totals.Count != 2 {
+		t.Errorf("Totals.Count = %d, want 2", r.Totals.Count)
+	}
+	if r.Totals.AmountMsat != 3000 {
+		t.Errorf("Totals.AmountMsat = %d, want 3000", r.Totals.AmountMsat)
+	}
+	if r.Totals.FeeMsat != 30 {
+		t.Errorf("Totals.FeeMsat = %d, want 30", r.Totals.FeeMsat)
+	}
+	if r.Totals.CountExcludingOpens != 2 {
+		t.Errorf("Totals.CountExcludingOpens = %d, want 2", r.Totals.CountExcludingOpens)
+	}
+}
+
+func TestComputeExcludesChannelOpensFromTotals(t *testing.T) {
+	forwards := []*nodeclient.Forward{
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 1000, FeeMsat: 10, ReceivedTime: 100},
+	}
+	lookup := map[string]string{"in1": "peerIn", "out1": "peerOut"}
+
+	r, err := Compute("node", 0, 1000, "", forwards, lookup, nil, stubClassifier(true), nil)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	if r.Totals.Count != 1 {
+		t.Errorf("Totals.Count = %d, want 1", r.Totals.Count)
+	}
+	if r.Totals.CountExcludingOpens != 0 {
+		t.Errorf("Totals.CountExcludingOpens = %d, want 0", r.Totals.CountExcludingOpens)
+	}
+}
+
+func TestComputeErrorsOnUnknownChannel(t *testing.T) {
+	forwards := []*nodeclient.Forward{
+		{InChannel: "in1", OutChannel: "unknown", OutMsat: 1000, ReceivedTime: 100},
+	}
+	lookup := map[string]string{"in1": "peerIn"}
+
+	if _, err := Compute("node", 0, 1000, "", forwards, lookup, nil, stubClassifier(false), nil); err == nil {
+		t.Fatal("Compute() error = nil, want error for channel missing from lookup")
+	}
+}
+
+func TestComputeBucketsByGranularity(t *testing.T) {
+	forwards := []*nodeclient.Forward{
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 1000, ReceivedTime: 0},
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 2000, ReceivedTime: 3700},
+	}
+	lookup := map[string]string{"in1": "peerIn", "out1": "peerOut"}
+
+	r, err := Compute("node", 0, 7200, GranularityHour, forwards, lookup, nil, stubClassifier(false), nil)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	if len(r.Series) != 2 {
+		t.Fatalf("len(Series) = %d, want 2 buckets", len(r.Series))
+	}
+	if r.Series[0].Totals.AmountMsat != 1000 || r.Series[1].Totals.AmountMsat != 2000 {
+		t.Errorf("Series amounts = %v, want [1000, 2000]", []uint64{r.Series[0].Totals.AmountMsat, r.Series[1].Totals.AmountMsat})
+	}
+}
+
+func TestComputePeerGroupRouting(t *testing.T) {
+	forwards := []*nodeclient.Forward{
+		{InChannel: "in1", OutChannel: "out1", OutMsat: 1000, FeeMsat: 5, ReceivedTime: 100},
+		{InChannel: "in2", OutChannel: "out2", OutMsat: 1000, FeeMsat: 5, ReceivedTime: 100},
+	}
+	lookup := map[string]string{
+		"in1": "peerA", "out1": "peerB",
+		"in2": "peerA", "out2": "peerOutside",
+	}
+	peerGroups := []PeerGroup{
+		{Name: "group", Peers: []RoutingPeer{{Name: "a", Pubkey: "peerA"}, {Name: "b", Pubkey: "peerB"}}},
+	}
+
+	r, err := Compute("node", 0, 1000, "", forwards, lookup, peerGroups, stubClassifier(false), nil)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	if len(r.PeerGroups) != 1 {
+		t.Fatalf("len(PeerGroups) = %d, want 1", len(r.PeerGroups))
+	}
+	if r.PeerGroups[0].Routing.Count != 1 {
+		t.Errorf("group Routing.Count = %d, want 1 (only the in1->out1 forward is between two group peers)", r.PeerGroups[0].Routing.Count)
+	}
+}
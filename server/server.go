@@ -0,0 +1,161 @@
+// Package server exposes lsp-node-stats reports over HTTP, as JSON for
+// ad-hoc queries and as Prometheus metrics for continuous scraping.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/JssDWt/lsp-node-stats/config"
+	"github.com/JssDWt/lsp-node-stats/report"
+	"github.com/JssDWt/lsp-node-stats/store"
+)
+
+// Server serves reports computed from a Store over HTTP, for every node in
+// config plus a synthetic "combined" node covering all of them.
+type Server struct {
+	store      *store.Store
+	nodes      []config.NodeConfig
+	peerGroups []report.PeerGroup
+	config     *config.Config
+	mux        *http.ServeMux
+}
+
+// New builds a Server reporting on every node in cfg, using s as its data
+// source.
+func New(s *store.Store, cfg *config.Config) *Server {
+	srv := &Server{
+		store:      s,
+		nodes:      cfg.Nodes,
+		peerGroups: report.PeerGroupsFromConfig(cfg),
+		config:     cfg,
+		mux:        http.NewServeMux(),
+	}
+	srv.mux.HandleFunc("/report", srv.handleReport)
+	srv.mux.HandleFunc("/metrics", srv.handleMetrics)
+	return srv
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.mux)
+}
+
+// handleReport serves GET /report?node=breezc&from=2024-04-01&to=2024-05-01
+// as JSON. node defaults to the first configured node, and may also be
+// "combined" for the merged cross-LSP report.
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	node := r.URL.Query().Get("node")
+	if node == "" && len(s.nodes) > 0 {
+		node = s.nodes[0].Name
+	}
+
+	start, end, err := parseRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	granularity := report.Granularity(r.URL.Query().Get("bucket"))
+
+	rep, err := s.computeReport(node, start, end, granularity)
+	if err != nil {
+		if errors.Is(err, errUnknownNode) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rep); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleMetrics serves GET /metrics in Prometheus text exposition format,
+// covering the current calendar month for every configured node and, when
+// more than one node is configured, the combined cross-LSP report.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+
+	nodeNames := make([]string, 0, len(s.nodes)+1)
+	for _, node := range s.nodes {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	if len(s.nodes) > 1 {
+		nodeNames = append(nodeNames, report.CombinedNode)
+	}
+
+	// Compute every node's report before writing anything, so a failure
+	// partway through doesn't leave the scraper with a truncated body and a
+	// 200 status that already went out with the headers.
+	reports := make([]*report.Report, 0, len(nodeNames))
+	for _, node := range nodeNames {
+		rep, err := s.computeReport(node, float64(start.Unix()), float64(end.Unix()), "")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		reports = append(reports, rep)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, rep := range reports {
+		labels := fmt.Sprintf(`node=%q`, rep.Node)
+		fmt.Fprintf(w, "lsp_forward_count{%s} %d\n", labels, rep.Totals.Count)
+		fmt.Fprintf(w, "lsp_forward_amount_msat{%s} %d\n", labels, rep.Totals.AmountMsat)
+		fmt.Fprintf(w, "lsp_forward_fee_msat{%s} %d\n", labels, rep.Totals.FeeMsat)
+
+		for _, group := range rep.PeerGroups {
+			groupLabels := fmt.Sprintf(`node=%q,peer_group=%q`, rep.Node, group.Name)
+			fmt.Fprintf(w, "lsp_routing_forward_count{%s} %d\n", groupLabels, group.Routing.Count)
+			fmt.Fprintf(w, "lsp_routing_forward_amount_msat{%s} %d\n", groupLabels, group.Routing.AmountMsat)
+			fmt.Fprintf(w, "lsp_routing_forward_fee_msat{%s} %d\n", groupLabels, group.Routing.FeeMsat)
+		}
+	}
+}
+
+// errUnknownNode is returned by computeReport when node doesn't match any
+// configured node or the combined node.
+var errUnknownNode = fmt.Errorf("unknown node")
+
+func (s *Server) computeReport(node string, start, end float64, granularity report.Granularity) (*report.Report, error) {
+	if node == report.CombinedNode {
+		return report.LoadCombined(s.store, s.nodes, start, end, granularity, s.peerGroups, s.config)
+	}
+
+	found := false
+	for _, n := range s.nodes {
+		if n.Name == node {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: %w", node, errUnknownNode)
+	}
+
+	return report.Load(s.store, node, start, end, granularity, s.peerGroups, s.config)
+}
+
+func parseRange(from, to string) (start, end float64, err error) {
+	if from == "" || to == "" {
+		return 0, 0, fmt.Errorf("from and to query params are required, format YYYY-MM-DD")
+	}
+
+	startTime, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid from date %q: %w", from, err)
+	}
+	endTime, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid to date %q: %w", to, err)
+	}
+
+	return float64(startTime.Unix()), float64(endTime.Unix()), nil
+}
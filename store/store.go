@@ -0,0 +1,308 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+	_ "modernc.org/sqlite"
+)
+
+// Store is the embedded SQLite-backed store for forwarding history and
+// channel snapshots. It replaces holding every gzip dump in memory, so
+// multi-month reports no longer require re-parsing the raw JSON each run.
+type Store struct {
+	db *sql.DB
+}
+
+// migrations is applied in order, starting right after the highest version
+// already recorded in schema_version. Each entry is one migration step.
+var migrations = []string{
+	`CREATE TABLE schema_version (version INTEGER NOT NULL);`,
+
+	`CREATE TABLE forwards (
+		in_channel    TEXT NOT NULL,
+		out_channel   TEXT NOT NULL,
+		fee_msat      INTEGER NOT NULL,
+		out_msat      INTEGER NOT NULL,
+		received_time REAL NOT NULL,
+		PRIMARY KEY (in_channel, out_channel, received_time)
+	);
+	CREATE INDEX idx_forwards_received_time ON forwards(received_time);`,
+
+	`CREATE TABLE channels (
+		short_channel_id TEXT NOT NULL PRIMARY KEY,
+		local_alias      TEXT,
+		remote_alias     TEXT,
+		peer_id          TEXT NOT NULL,
+		closed           INTEGER NOT NULL DEFAULT 0
+	);`,
+
+	`CREATE TABLE channel_peers (
+		short_channel_id TEXT NOT NULL PRIMARY KEY,
+		alias            TEXT,
+		peer_id          TEXT NOT NULL
+	);
+	CREATE INDEX idx_channel_peers_alias ON channel_peers(alias);
+	CREATE INDEX idx_channel_peers_peer_id ON channel_peers(peer_id);`,
+
+	`ALTER TABLE channels ADD COLUMN funding_time REAL NOT NULL DEFAULT 0;`,
+
+	// Scope every table by node, so one store can hold forwards and channels
+	// for several LSPs. Existing rows are assumed to belong to "breezc", the
+	// only node lsp-node-stats supported before multi-LSP support.
+	`ALTER TABLE forwards RENAME TO forwards_old;
+	DROP INDEX idx_forwards_received_time;
+	CREATE TABLE forwards (
+		node          TEXT NOT NULL,
+		in_channel    TEXT NOT NULL,
+		out_channel   TEXT NOT NULL,
+		fee_msat      INTEGER NOT NULL,
+		out_msat      INTEGER NOT NULL,
+		received_time REAL NOT NULL,
+		PRIMARY KEY (node, in_channel, out_channel, received_time)
+	);
+	CREATE INDEX idx_forwards_received_time ON forwards(received_time);
+	INSERT INTO forwards (node, in_channel, out_channel, fee_msat, out_msat, received_time)
+		SELECT 'breezc', in_channel, out_channel, fee_msat, out_msat, received_time FROM forwards_old;
+	DROP TABLE forwards_old;
+
+	ALTER TABLE channels RENAME TO channels_old;
+	CREATE TABLE channels (
+		node             TEXT NOT NULL,
+		short_channel_id TEXT NOT NULL,
+		local_alias      TEXT,
+		remote_alias     TEXT,
+		peer_id          TEXT NOT NULL,
+		closed           INTEGER NOT NULL DEFAULT 0,
+		funding_time     REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY (node, short_channel_id)
+	);
+	INSERT INTO channels (node, short_channel_id, local_alias, remote_alias, peer_id, closed, funding_time)
+		SELECT 'breezc', short_channel_id, local_alias, remote_alias, peer_id, closed, funding_time FROM channels_old;
+	DROP TABLE channels_old;
+
+	ALTER TABLE channel_peers RENAME TO channel_peers_old;
+	DROP INDEX idx_channel_peers_alias;
+	DROP INDEX idx_channel_peers_peer_id;
+	CREATE TABLE channel_peers (
+		node             TEXT NOT NULL,
+		short_channel_id TEXT NOT NULL,
+		alias            TEXT,
+		peer_id          TEXT NOT NULL,
+		PRIMARY KEY (node, short_channel_id)
+	);
+	CREATE INDEX idx_channel_peers_alias ON channel_peers(alias);
+	CREATE INDEX idx_channel_peers_peer_id ON channel_peers(peer_id);
+	INSERT INTO channel_peers (node, short_channel_id, alias, peer_id)
+		SELECT 'breezc', short_channel_id, alias, peer_id FROM channel_peers_old;
+	DROP TABLE channel_peers_old;`,
+
+	// channel_peers duplicated channels (short_channel_id, alias, peer_id),
+	// kept in sync by hand on every upsert for no behavioral benefit: channels
+	// already has local_alias and peer_id, so ChannelPeerLookup can query it
+	// directly instead.
+	`DROP TABLE channel_peers;`,
+}
+
+// NewStore opens (or creates) the SQLite database at path and brings its
+// schema up to date.
+func NewStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	// SQLite only allows one writer at a time; serialize all access through a
+	// single connection so concurrent ingestion (see main.go's ingestAll)
+	// blocks on Go's connection pool instead of failing with SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate store: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) schemaVersion() (int, error) {
+	var exists int
+	err := s.db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type='table' AND name='schema_version'`).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check for schema_version table: %w", err)
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	var version int
+	err = s.db.QueryRow(`SELECT coalesce(max(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+func (s *Store) migrate() error {
+	version, err := s.schemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", i+1, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_version (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// UpsertForwards inserts forwards for node that aren't already present,
+// identified by their (node, in_channel, out_channel, received_time)
+// primary key. Re-ingesting a dump that overlaps with already-ingested data
+// is therefore a no-op for the overlapping rows.
+func (s *Store) UpsertForwards(node string, forwards []*nodeclient.Forward) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin forwards upsert: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO forwards (node, in_channel, out_channel, fee_msat, out_msat, received_time) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare forwards upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	var inserted int64
+	for _, f := range forwards {
+		res, err := stmt.Exec(node, f.InChannel, f.OutChannel, f.FeeMsat, f.OutMsat, f.ReceivedTime)
+		if err != nil {
+			return 0, fmt.Errorf("failed to upsert forward: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read rows affected: %w", err)
+		}
+		inserted += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit forwards upsert: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// UpsertChannels replaces node's stored snapshot for each channel's
+// short_channel_id.
+func (s *Store) UpsertChannels(node string, channels []*nodeclient.Channel, closed bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin channels upsert: %w", err)
+	}
+	defer tx.Rollback()
+
+	channelStmt, err := tx.Prepare(`INSERT OR REPLACE INTO channels (node, short_channel_id, local_alias, remote_alias, peer_id, closed, funding_time) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare channels upsert: %w", err)
+	}
+	defer channelStmt.Close()
+
+	for _, c := range channels {
+		if _, err := channelStmt.Exec(node, c.ShortChannelId, c.LocalAlias, c.RemoteAlias, c.Peer, closed, c.FundingTime); err != nil {
+			return fmt.Errorf("failed to upsert channel %s: %w", c.ShortChannelId, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit channels upsert: %w", err)
+	}
+
+	return nil
+}
+
+// Forwards returns node's forwards with received_time in [start, end).
+func (s *Store) Forwards(node string, start, end float64) ([]*nodeclient.Forward, error) {
+	rows, err := s.db.Query(`SELECT in_channel, out_channel, fee_msat, out_msat, received_time FROM forwards WHERE node = ? AND received_time >= ? AND received_time < ? ORDER BY received_time`, node, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forwards: %w", err)
+	}
+	defer rows.Close()
+
+	var forwards []*nodeclient.Forward
+	for rows.Next() {
+		f := &nodeclient.Forward{}
+		if err := rows.Scan(&f.InChannel, &f.OutChannel, &f.FeeMsat, &f.OutMsat, &f.ReceivedTime); err != nil {
+			return nil, fmt.Errorf("failed to scan forward: %w", err)
+		}
+		forwards = append(forwards, f)
+	}
+
+	return forwards, rows.Err()
+}
+
+// FundingTimes returns a map from short_channel_id to the channel's funding
+// time, for node's channels where the node client reported one.
+func (s *Store) FundingTimes(node string) (map[string]float64, error) {
+	rows, err := s.db.Query(`SELECT short_channel_id, funding_time FROM channels WHERE node = ? AND funding_time > 0`, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query funding times: %w", err)
+	}
+	defer rows.Close()
+
+	fundingTimes := make(map[string]float64)
+	for rows.Next() {
+		var scid string
+		var fundingTime float64
+		if err := rows.Scan(&scid, &fundingTime); err != nil {
+			return nil, fmt.Errorf("failed to scan funding time row: %w", err)
+		}
+		fundingTimes[scid] = fundingTime
+	}
+
+	return fundingTimes, rows.Err()
+}
+
+// ChannelPeerLookup returns a map from short_channel_id and, where set, local
+// alias to peer pubkey for node's stored channels, open and closed.
+func (s *Store) ChannelPeerLookup(node string) (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT short_channel_id, local_alias, peer_id FROM channels WHERE node = ?`, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query channels: %w", err)
+	}
+	defer rows.Close()
+
+	lookup := make(map[string]string)
+	for rows.Next() {
+		var scid, alias, peer string
+		if err := rows.Scan(&scid, &alias, &peer); err != nil {
+			return nil, fmt.Errorf("failed to scan channel row: %w", err)
+		}
+		lookup[scid] = peer
+		if alias != "" {
+			lookup[alias] = peer
+		}
+	}
+
+	return lookup, rows.Err()
+}
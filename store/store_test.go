@@ -0,0 +1,46 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/JssDWt/lsp-node-stats/nodeclient"
+)
+
+func TestNewStoreMigratesFreshDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.UpsertForwards("node", []*nodeclient.Forward{
+		{InChannel: "in1", OutChannel: "out1", FeeMsat: 1, OutMsat: 1000, ReceivedTime: 100},
+	}); err != nil {
+		t.Fatalf("UpsertForwards() error = %v", err)
+	}
+
+	if err := s.UpsertChannels("node", []*nodeclient.Channel{
+		{ShortChannelId: "out1", LocalAlias: "alias", Peer: "peer", FundingTime: 50},
+	}, false); err != nil {
+		t.Fatalf("UpsertChannels() error = %v", err)
+	}
+}
+
+func TestNewStoreReopenIsANoop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	s1, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	s1.Close()
+
+	s2, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("re-opening NewStore() error = %v", err)
+	}
+	defer s2.Close()
+}